@@ -2,90 +2,175 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
-	"encoding/json"
+	"encoding/hex"
 	"fmt"
-	"io"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
-	"github.com/aws/smithy-go/logging"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hoophq/pluginhooks"
+
+	"github.com/hoophq/plugin-secretsmanager/providers"
+	_ "github.com/hoophq/plugin-secretsmanager/providers/aws"
+	_ "github.com/hoophq/plugin-secretsmanager/providers/awsssm"
+	_ "github.com/hoophq/plugin-secretsmanager/providers/azurekv"
+	"github.com/hoophq/plugin-secretsmanager/providers/cache"
+	_ "github.com/hoophq/plugin-secretsmanager/providers/envjson"
+	_ "github.com/hoophq/plugin-secretsmanager/providers/gcpsm"
+	_ "github.com/hoophq/plugin-secretsmanager/providers/vault"
+	"github.com/hoophq/plugin-secretsmanager/redact"
+)
+
+const (
+	defaultSecretCacheTTL = 5 * time.Minute
+	defaultSecretCacheMax = 256
 )
 
 type secretManager struct {
 	logger    hclog.Logger
-	awsLogger *awsLogger
 	params    *pluginhooks.SesssionParams
+	redaction *redact.Table
+
+	attrsMu sync.Mutex
+	attrs   map[string]*valAttr
 }
 
-type secretProviderType string
+// valAttrFor returns the valAttr for pluginEnvVars, building one if this is
+// the first session seen with this exact config. pluginEnvVars is
+// per-session (PluginEnvVars carries per-connection AWS role ARNs, Vault
+// tokens, endpoint overrides, ...), so valAttr instances are keyed by a
+// fingerprint of the whole config rather than shared process-wide: sessions
+// with identical config still share the cached providers (and their
+// cache.Wrap TTL cache/singleflight group), but a session with different
+// config never reuses another session's clients or credentials.
+func (s *secretManager) valAttrFor(pluginEnvVars map[string]string) (*valAttr, error) {
+	key := configFingerprint(pluginEnvVars)
+	s.attrsMu.Lock()
+	defer s.attrsMu.Unlock()
+	if s.attrs == nil {
+		s.attrs = map[string]*valAttr{}
+	}
+	if a, ok := s.attrs[key]; ok {
+		return a, nil
+	}
+	a, err := newValAttr(pluginEnvVars)
+	if err != nil {
+		return nil, err
+	}
+	s.attrs[key] = a
+	return a, nil
+}
 
-const (
-	// fetch secrets from aws secrets manager
-	secretProviderAWSSecretsManager secretProviderType = "aws"
-	// fetches secrets from environment variables mapped as json in unix environments
-	secretProviderEnvJSON secretProviderType = "envjson"
-)
+// configFingerprint hashes every key/value in pluginEnvVars so two sessions
+// are only ever routed to the same valAttr when their plugin config is
+// identical.
+func configFingerprint(pluginEnvVars map[string]string) string {
+	keys := make([]string, 0, len(pluginEnvVars))
+	for k := range pluginEnvVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(pluginEnvVars[k]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
 
+// valAttr dispatches connection values to the registered providers,
+// instantiating (and caching) each scheme's client lazily on first use, for
+// a single fixed pluginEnvVars config. secretManager reuses a valAttr across
+// every session sharing that config, so the per-scheme cache.Wrap TTL cache
+// and singleflight group actually accumulate hits instead of being rebuilt
+// (and discarded) on every call.
 type valAttr struct {
-	smService *secretsmanager.Client
+	pluginEnvVars map[string]string
+
+	mu        sync.Mutex
+	providers map[string]providers.Provider
+}
+
+func newValAttr(pluginEnvVars map[string]string) (*valAttr, error) {
+	return &valAttr{
+		pluginEnvVars: pluginEnvVars,
+		providers:     map[string]providers.Provider{},
+	}, nil
 }
 
-func newValAttr(pluginEnvVars map[string]string, wLogger io.Writer) (*valAttr, error) {
-	cfg, err := config.LoadDefaultConfig(context.Background())
+func (a *valAttr) providerFor(scheme string) (providers.Provider, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if p, ok := a.providers[scheme]; ok {
+		return p, nil
+	}
+	p, err := providers.New(scheme, a.pluginEnvVars)
 	if err != nil {
 		return nil, err
 	}
-	svc := secretsmanager.NewFromConfig(cfg, func(o *secretsmanager.Options) {
-		o.ClientLogMode = aws.LogSigning | aws.LogRequest | aws.LogResponseWithBody
-		o.Logger = logging.NewStandardLogger(wLogger)
-	})
-	return &valAttr{smService: svc}, nil
+	p = cache.Wrap(p, secretCacheTTL(a.pluginEnvVars), secretCacheMax(a.pluginEnvVars))
+	a.providers[scheme] = p
+	return p, nil
+}
+
+// secretCacheTTL reads PLUGIN_SECRET_CACHE_TTL (a Go duration string, e.g.
+// "30s") from pluginEnvVars, falling back to defaultSecretCacheTTL.
+func secretCacheTTL(pluginEnvVars map[string]string) time.Duration {
+	raw := pluginEnvVars["PLUGIN_SECRET_CACHE_TTL"]
+	if raw == "" {
+		return defaultSecretCacheTTL
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil {
+		return defaultSecretCacheTTL
+	}
+	return ttl
+}
+
+// secretCacheMax reads PLUGIN_SECRET_CACHE_MAX (max cached entries per
+// provider) from pluginEnvVars, falling back to defaultSecretCacheMax.
+func secretCacheMax(pluginEnvVars map[string]string) int {
+	raw := pluginEnvVars["PLUGIN_SECRET_CACHE_MAX"]
+	if raw == "" {
+		return defaultSecretCacheMax
+	}
+	max, err := strconv.Atoi(raw)
+	if err != nil || max <= 0 {
+		return defaultSecretCacheMax
+	}
+	return max
 }
 
-// <provider>:<secret-id>:<secret-key>
+// <provider>:<secret-id>:<secret-key>[?version=...|stage=...]
 func (a *valAttr) parseConnectionVal(val string) (string, error) {
-	parts := strings.Split(val, ":")
+	parts := strings.SplitN(val, ":", 3)
 	if len(parts) != 3 {
 		return "", nil
 	}
-	secretProvider, secretID, secretKey := secretProviderType(parts[0]), parts[1], parts[2]
-	switch secretProvider {
-	case secretProviderAWSSecretsManager:
-		if a.smService == nil {
-			return "", fmt.Errorf("secret manager is missing required aws credentials")
-		}
-		keyVal, err := getAWSSecretValue(a.smService, secretID)
-		if err != nil {
-			return "", fmt.Errorf("failed to get %s/%s, err=%v", secretID, secretKey, err)
-		}
-		secretVal, ok := keyVal[secretKey]
-		if !ok {
-			return "", fmt.Errorf("key not found, secretid=%s, secretkey=%s",
-				secretID, secretKey)
-		}
-		return string(secretVal), nil
-	case secretProviderEnvJSON:
-		envJson := os.Getenv(secretID)
-		if envJson == "" {
-			return "", fmt.Errorf("env not found for secret id %q", secretID)
-		}
-		var envMap map[string]string
-		if err := json.Unmarshal([]byte(envJson), &envMap); err != nil {
-			return "", fmt.Errorf("failed decoding secret id %q to json, err=%v", secretID, err)
-		}
-		val, ok := envMap[secretKey]
-		if !ok {
-			return "", fmt.Errorf("secret key %q not found in secret id %q", secretKey, secretID)
-		}
-		return val, nil
+	scheme, secretID, secretKeyAndQuery := parts[0], parts[1], parts[2]
+	secretKey, rawQuery, _ := strings.Cut(secretKeyAndQuery, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("failed parsing query suffix of %q, err=%v", val, err)
+	}
+	provider, err := a.providerFor(scheme)
+	if err != nil {
+		return "", err
+	}
+	secretVal, err := provider.Resolve(context.Background(), secretID, secretKey, query)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s/%s, err=%v", secretID, secretKey, err)
 	}
-	return "", fmt.Errorf("secret provider %q not implemented", secretProvider)
+	return secretVal, nil
 }
 
 func (s *secretManager) logRedactVal(envKey string, val string) {
@@ -98,6 +183,7 @@ func (s *secretManager) logRedactVal(envKey string, val string) {
 
 func (s *secretManager) secretManagerGetter(params *pluginhooks.SesssionParams) (map[string]any, error) {
 	s.logger.Debug("plugin env vars", "length", len(params.PluginEnvVars))
+	pluginEnvVars := make(map[string]string, len(params.PluginEnvVars))
 	for key, val := range params.PluginEnvVars {
 		decVal, err := base64.StdEncoding.DecodeString(val)
 		if err != nil {
@@ -107,12 +193,14 @@ func (s *secretManager) secretManagerGetter(params *pluginhooks.SesssionParams)
 		if err := os.Setenv(key, string(decVal)); err != nil {
 			return nil, fmt.Errorf("failed configuring plugin config env %v, err=%v", key, err)
 		}
+		pluginEnvVars[key] = string(decVal)
 	}
-	attrInstance, err := newValAttr(params.PluginEnvVars, s.awsLogger)
+	attr, err := s.valAttrFor(pluginEnvVars)
 	if err != nil {
 		return nil, err
 	}
 	var responseConnEnvVar map[string]any
+	var resolvedSecrets []string
 	for envKey, val := range params.ConnectionEnvVars {
 		encVal, ok := val.(string)
 		if !ok {
@@ -126,7 +214,7 @@ func (s *secretManager) secretManagerGetter(params *pluginhooks.SesssionParams)
 		if responseConnEnvVar == nil {
 			responseConnEnvVar = map[string]any{}
 		}
-		secretVal, err := attrInstance.parseConnectionVal(string(decVal))
+		secretVal, err := attr.parseConnectionVal(string(decVal))
 		if err != nil {
 			return nil, err
 		}
@@ -135,8 +223,10 @@ func (s *secretManager) secretManagerGetter(params *pluginhooks.SesssionParams)
 			continue
 		}
 		s.logRedactVal(envKey, secretVal)
+		resolvedSecrets = append(resolvedSecrets, secretVal)
 		responseConnEnvVar[envKey] = base64.StdEncoding.EncodeToString([]byte(secretVal))
 	}
+	s.redaction.Set(params.SessionID, resolvedSecrets)
 	return responseConnEnvVar, nil
 }
 
@@ -158,43 +248,16 @@ func (s *secretManager) OnSessionOpen(params *pluginhooks.SesssionParams, resp *
 
 func (s *secretManager) OnReceive(req *pluginhooks.Request, resp *pluginhooks.Response) error {
 	s.logger.Debug("on-receive", "session", req.SessionID, "verb", s.params.ClientVerb)
+	resp.Payload = s.redaction.Redact(req.SessionID, req.Payload)
 	return nil
 }
 
 func (s *secretManager) OnSend(req *pluginhooks.Request, resp *pluginhooks.Response) error {
 	s.logger.Debug("on-send", "session", req.SessionID)
+	resp.Payload = s.redaction.Redact(req.SessionID, req.Payload)
 	return nil
 }
 
-type awsLogger struct {
-	logger hclog.Logger
-}
-
-func (w *awsLogger) Write(b []byte) (int, error) {
-	if w.logger != nil && b != nil {
-		w.logger.Debug(string(b), "lib", "aws")
-	}
-	return 0, nil
-}
-
-func getAWSSecretValue(svc *secretsmanager.Client, secretID string) (map[string]string, error) {
-	if svc == nil {
-		return nil, fmt.Errorf("secret manager not configured")
-	}
-	input := &secretsmanager.GetSecretValueInput{
-		SecretId: aws.String(secretID),
-	}
-	result, err := svc.GetSecretValue(context.Background(), input)
-	if err != nil {
-		return nil, err
-	}
-	var keyValSecret map[string]string
-	if err := json.Unmarshal([]byte(*result.SecretString), &keyValSecret); err != nil {
-		return nil, fmt.Errorf("failed deserializing secret key/val")
-	}
-	return keyValSecret, nil
-}
-
 func main() {
 	logLevel := strings.ToLower(os.Getenv("LOG_LEVEL"))
 	hcLogLevel := hclog.Info
@@ -211,13 +274,9 @@ func main() {
 		IndependentLevels: true,
 		JSONFormat:        true,
 	})
-	awslogger := &awsLogger{logger: nil}
-	if logLevel == "trace" {
-		awslogger.logger = logger
-	}
-	logger.Info("starting plugin secretmanager", "awslogger", logLevel == "trace")
+	logger.Info("starting plugin secretmanager")
 	pluginhooks.Serve(&secretManager{
 		logger:    logger,
-		awsLogger: awslogger,
+		redaction: redact.NewTable(),
 	})
 }
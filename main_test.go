@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hoophq/pluginhooks"
+
+	"github.com/hoophq/plugin-secretsmanager/providers/aws/awsmock"
+	"github.com/hoophq/plugin-secretsmanager/redact"
+)
+
+func b64(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+// TestSessionOpenResolvesAndRedacts drives OnSessionOpen end-to-end against
+// an awsmock.Server, then checks OnReceive redacts the resolved secret out
+// of a packet payload.
+func TestSessionOpenResolvesAndRedacts(t *testing.T) {
+	srv := awsmock.New(map[string]awsmock.Secret{
+		"db/prod": {SecretString: `{"password":"s3cr3t"}`},
+	})
+	defer srv.Close()
+
+	sm := &secretManager{
+		logger:    hclog.NewNullLogger(),
+		redaction: redact.NewTable(),
+	}
+
+	params := &pluginhooks.SesssionParams{
+		SessionID: "session-1",
+		PluginEnvVars: map[string]string{
+			"AWS_REGION":            b64("us-east-1"),
+			"AWS_ACCESS_KEY_ID":     b64("test"),
+			"AWS_SECRET_ACCESS_KEY": b64("test"),
+			"AWS_ENDPOINT_URL":      b64(srv.URL),
+		},
+		ConnectionEnvVars: map[string]any{
+			"PGPASSWORD": b64("aws:db/prod:password"),
+		},
+	}
+
+	var resp pluginhooks.SessionParamsResponse
+	if err := sm.OnSessionOpen(params, &resp); err != nil {
+		t.Fatalf("OnSessionOpen: %v", err)
+	}
+	encVal, ok := resp.ConnectionEnvVars["PGPASSWORD"].(string)
+	if !ok {
+		t.Fatalf("expected PGPASSWORD to be set, got %#v", resp.ConnectionEnvVars)
+	}
+	decVal, err := base64.StdEncoding.DecodeString(encVal)
+	if err != nil {
+		t.Fatalf("decode resolved secret: %v", err)
+	}
+	if string(decVal) != "s3cr3t" {
+		t.Fatalf("got %q, want %q", decVal, "s3cr3t")
+	}
+
+	req := &pluginhooks.Request{SessionID: "session-1", Payload: []byte("connecting with password=s3cr3t")}
+	var receiveResp pluginhooks.Response
+	if err := sm.OnReceive(req, &receiveResp); err != nil {
+		t.Fatalf("OnReceive: %v", err)
+	}
+	if string(receiveResp.Payload) != "connecting with password=#######" {
+		t.Fatalf("payload not redacted: %q", receiveResp.Payload)
+	}
+}
+
+// TestSessionOpenReusesProviderCacheAcrossCalls guards against rebuilding
+// valAttr (and therefore its cache.Wrap'd providers) on every OnSessionOpen
+// call, which would silently throw away the TTL cache and singleflight
+// coalescing and re-hit the backend on every session.
+func TestSessionOpenReusesProviderCacheAcrossCalls(t *testing.T) {
+	srv := awsmock.New(map[string]awsmock.Secret{
+		"db/prod": {SecretString: `{"password":"s3cr3t"}`},
+	})
+	defer srv.Close()
+
+	sm := &secretManager{
+		logger:    hclog.NewNullLogger(),
+		redaction: redact.NewTable(),
+	}
+
+	params := &pluginhooks.SesssionParams{
+		SessionID: "session-1",
+		PluginEnvVars: map[string]string{
+			"AWS_REGION":            b64("us-east-1"),
+			"AWS_ACCESS_KEY_ID":     b64("test"),
+			"AWS_SECRET_ACCESS_KEY": b64("test"),
+			"AWS_ENDPOINT_URL":      b64(srv.URL),
+		},
+		ConnectionEnvVars: map[string]any{
+			"PGPASSWORD": b64("aws:db/prod:password"),
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		var resp pluginhooks.SessionParamsResponse
+		if err := sm.OnSessionOpen(params, &resp); err != nil {
+			t.Fatalf("OnSessionOpen #%d: %v", i, err)
+		}
+	}
+	if got := srv.RequestCount(); got != 1 {
+		t.Fatalf("backend hit %d times across 3 OnSessionOpen calls, want 1 (cache not reused)", got)
+	}
+}
+
+// TestSessionOpenScopesProviderByConfig guards against two sessions with
+// different PluginEnvVars (e.g. distinct AWS_ENDPOINT_URL/credentials, as
+// chunk0-6's per-connection IAM role assumption produces) sharing a valAttr:
+// each session's ConnectionEnvVars must resolve against its own backend.
+func TestSessionOpenScopesProviderByConfig(t *testing.T) {
+	srvA := awsmock.New(map[string]awsmock.Secret{
+		"db/prod": {SecretString: `{"password":"tenant-a-secret"}`},
+	})
+	defer srvA.Close()
+	srvB := awsmock.New(map[string]awsmock.Secret{
+		"db/prod": {SecretString: `{"password":"tenant-b-secret"}`},
+	})
+	defer srvB.Close()
+
+	sm := &secretManager{
+		logger:    hclog.NewNullLogger(),
+		redaction: redact.NewTable(),
+	}
+
+	resolve := func(sessionID string, srv *awsmock.Server) string {
+		params := &pluginhooks.SesssionParams{
+			SessionID: sessionID,
+			PluginEnvVars: map[string]string{
+				"AWS_REGION":            b64("us-east-1"),
+				"AWS_ACCESS_KEY_ID":     b64("test"),
+				"AWS_SECRET_ACCESS_KEY": b64("test"),
+				"AWS_ENDPOINT_URL":      b64(srv.URL),
+			},
+			ConnectionEnvVars: map[string]any{
+				"PGPASSWORD": b64("aws:db/prod:password"),
+			},
+		}
+		var resp pluginhooks.SessionParamsResponse
+		if err := sm.OnSessionOpen(params, &resp); err != nil {
+			t.Fatalf("OnSessionOpen %s: %v", sessionID, err)
+		}
+		encVal, ok := resp.ConnectionEnvVars["PGPASSWORD"].(string)
+		if !ok {
+			t.Fatalf("%s: expected PGPASSWORD to be set, got %#v", sessionID, resp.ConnectionEnvVars)
+		}
+		decVal, err := base64.StdEncoding.DecodeString(encVal)
+		if err != nil {
+			t.Fatalf("%s: decode resolved secret: %v", sessionID, err)
+		}
+		return string(decVal)
+	}
+
+	if got := resolve("session-a", srvA); got != "tenant-a-secret" {
+		t.Fatalf("session-a resolved %q, want tenant-a-secret", got)
+	}
+	if got := resolve("session-b", srvB); got != "tenant-b-secret" {
+		t.Fatalf("session-b resolved %q, want tenant-b-secret (got tenant-a's backend/cache instead)", got)
+	}
+}
@@ -0,0 +1,117 @@
+// Package awsmock spins up an in-process HTTP server speaking just enough
+// of the AWS Secrets Manager JSON 1.1 protocol to drive real aws-sdk-go-v2
+// calls in tests, without requiring real AWS credentials.
+package awsmock
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// Secret is a canned secret served by Server, keyed by secret ID.
+type Secret struct {
+	SecretString string
+	SecretBinary []byte
+}
+
+// Server is an in-process fake of the Secrets Manager GetSecretValue API.
+type Server struct {
+	*httptest.Server
+
+	secrets  map[string]Secret
+	throttle int
+	requests atomic.Int64
+}
+
+// New starts a Server seeded with secrets.
+func New(secrets map[string]Secret) *Server {
+	s := &Server{secrets: secrets}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// ThrottleNext makes the next n requests fail with ThrottlingException,
+// to exercise client-side retry/backoff handling.
+func (s *Server) ThrottleNext(n int) {
+	s.throttle = n
+}
+
+// RequestCount returns how many GetSecretValue requests this Server has
+// handled so far, so tests can assert a caching layer actually avoided
+// re-hitting the backend.
+func (s *Server) RequestCount() int64 {
+	return s.requests.Load()
+}
+
+// Client builds a *secretsmanager.Client wired to this Server, with static
+// test credentials and retries disabled so throttling tests stay fast.
+func (s *Server) Client(ctx context.Context) (*secretsmanager.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx,
+		config.WithRegion("us-east-1"),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("test", "test", "")),
+		config.WithRetryMaxAttempts(1),
+		config.WithEndpointResolverWithOptions(
+			aws.EndpointResolverWithOptionsFunc(func(_, _ string, _ ...any) (aws.Endpoint, error) {
+				return aws.Endpoint{URL: s.URL}, nil
+			}),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return secretsmanager.NewFromConfig(cfg), nil
+}
+
+type getSecretValueInput struct {
+	SecretId string `json:"SecretId"`
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	if s.throttle > 0 {
+		s.throttle--
+		writeError(w, http.StatusTooManyRequests, "ThrottlingException", "Rate exceeded")
+		return
+	}
+
+	var in getSecretValueInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequestException", err.Error())
+		return
+	}
+	secret, ok := s.secrets[in.SecretId]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "ResourceNotFoundException",
+			fmt.Sprintf("Secrets Manager can't find the specified secret: %s", in.SecretId))
+		return
+	}
+
+	out := map[string]any{
+		"ARN":  fmt.Sprintf("arn:aws:secretsmanager:us-east-1:000000000000:secret:%s", in.SecretId),
+		"Name": in.SecretId,
+	}
+	if secret.SecretString != "" {
+		out["SecretString"] = secret.SecretString
+	}
+	if len(secret.SecretBinary) > 0 {
+		out["SecretBinary"] = base64.StdEncoding.EncodeToString(secret.SecretBinary)
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+func writeError(w http.ResponseWriter, status int, errType, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"__type": errType, "message": message})
+}
@@ -0,0 +1,169 @@
+// Package awscfg builds the aws.Config shared by the aws and awsssm
+// providers, optionally assuming an IAM role so multi-tenant deployments can
+// scope credentials per connection instead of relying on one ambient
+// identity.
+package awscfg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/logging"
+	"github.com/hashicorp/go-hclog"
+	"golang.org/x/sync/singleflight"
+)
+
+const defaultRoleSessionName = "hoop-plugin-secretsmanager"
+
+var (
+	mu    sync.Mutex
+	byKey = map[string]aws.Config{}
+
+	// group coalesces concurrent Load calls for the same key so they share a
+	// single loadBaseConfig/AssumeRole round trip, without holding mu (and
+	// therefore blocking every other key's Load) for the duration of it.
+	group singleflight.Group
+)
+
+// Load builds an aws.Config from pluginEnvVars.
+//
+// AWS_REGION and AWS_ENDPOINT_URL, when set, override the resolved region
+// and the service endpoint (for LocalStack-style local setups).
+//
+// AWS_ASSUME_ROLE_ARN, when set, makes the returned config assume that role
+// via sts.AssumeRole, scoped by AWS_ROLE_SESSION_NAME (defaults to
+// "hoop-plugin-secretsmanager") and AWS_EXTERNAL_ID. When
+// AWS_WEB_IDENTITY_TOKEN_FILE is also set (as injected by EKS for IRSA), the
+// role is assumed via sts.AssumeRoleWithWebIdentity instead.
+//
+// Configs are cached per role ARN + external ID + session name (or per
+// region/endpoint pair when no role is assumed) so repeated lookups across
+// sessions reuse the same credentials cache instead of re-assuming the role
+// on every call, without sharing credentials across connections that scope
+// the same role differently.
+//
+// LOG_LEVEL=trace additionally enables AWS SDK wire-level logging (signing,
+// request and response body) through a JSON hclog logger on stderr,
+// configured like the plugin's own.
+func Load(ctx context.Context, pluginEnvVars map[string]string) (aws.Config, error) {
+	roleArn := pluginEnvVars["AWS_ASSUME_ROLE_ARN"]
+	key := cacheKey(roleArn, pluginEnvVars)
+
+	mu.Lock()
+	cfg, ok := byKey[key]
+	mu.Unlock()
+	if ok {
+		return cfg, nil
+	}
+
+	// Build the config outside mu: loadBaseConfig/AssumeRole can do
+	// file/IMDS/STS I/O that stalls for seconds, and mu is shared by every
+	// connection's config load, so holding it here would make one slow or
+	// hanging key block session opens for every other tenant. group still
+	// coalesces concurrent Load calls for the same key into one round trip.
+	v, err, _ := group.Do(key, func() (any, error) {
+		cfg, err := loadBaseConfig(ctx, pluginEnvVars)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		if roleArn != "" {
+			cfg.Credentials = aws.NewCredentialsCache(assumeRoleProvider(cfg, roleArn, pluginEnvVars))
+		}
+
+		mu.Lock()
+		byKey[key] = cfg
+		mu.Unlock()
+		return cfg, nil
+	})
+	if err != nil {
+		return aws.Config{}, err
+	}
+	return v.(aws.Config), nil
+}
+
+func cacheKey(roleArn string, pluginEnvVars map[string]string) string {
+	if roleArn != "" {
+		// Two connections can assume the same role with different
+		// AWS_EXTERNAL_ID/AWS_ROLE_SESSION_NAME (the per-tenant scoping and
+		// audit-trail pattern this cache exists to support), so both must be
+		// part of the key or the second connection would silently reuse the
+		// first one's already-assumed-role credentials.
+		return fmt.Sprintf("role=%s;externalid=%s;sessionname=%s",
+			roleArn, pluginEnvVars["AWS_EXTERNAL_ID"], pluginEnvVars["AWS_ROLE_SESSION_NAME"])
+	}
+	return fmt.Sprintf("region=%s;endpoint=%s", pluginEnvVars["AWS_REGION"], pluginEnvVars["AWS_ENDPOINT_URL"])
+}
+
+func loadBaseConfig(ctx context.Context, pluginEnvVars map[string]string) (aws.Config, error) {
+	var opts []func(*config.LoadOptions) error
+	if region := pluginEnvVars["AWS_REGION"]; region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	if endpoint := pluginEnvVars["AWS_ENDPOINT_URL"]; endpoint != "" {
+		opts = append(opts, config.WithEndpointResolverWithOptions(
+			aws.EndpointResolverWithOptionsFunc(func(_, _ string, _ ...any) (aws.Endpoint, error) {
+				return aws.Endpoint{URL: endpoint}, nil
+			}),
+		))
+	}
+	if traceLogger := newTraceLogger(); traceLogger != nil {
+		opts = append(opts,
+			config.WithClientLogMode(aws.LogSigning|aws.LogRequest|aws.LogResponseWithBody),
+			config.WithLogger(traceLogger),
+		)
+	}
+	return config.LoadDefaultConfig(ctx, opts...)
+}
+
+// traceWriter forwards the AWS SDK's wire-level log lines to an hclog
+// logger, tagged the same way the plugin's own debug logs are.
+type traceWriter struct{ logger hclog.Logger }
+
+func (w traceWriter) Write(b []byte) (int, error) {
+	w.logger.Debug(string(b), "lib", "aws")
+	return len(b), nil
+}
+
+// newTraceLogger returns an SDK logger wired to log signing/request/response
+// bodies when LOG_LEVEL=trace, or nil otherwise.
+func newTraceLogger() logging.Logger {
+	if strings.ToLower(os.Getenv("LOG_LEVEL")) != "trace" {
+		return nil
+	}
+	logger := hclog.New(&hclog.LoggerOptions{
+		Output:            os.Stderr,
+		Level:             hclog.Debug,
+		DisableTime:       true,
+		IndependentLevels: true,
+		JSONFormat:        true,
+	})
+	return logging.NewStandardLogger(traceWriter{logger: logger})
+}
+
+func assumeRoleProvider(cfg aws.Config, roleArn string, pluginEnvVars map[string]string) aws.CredentialsProvider {
+	sessionName := pluginEnvVars["AWS_ROLE_SESSION_NAME"]
+	if sessionName == "" {
+		sessionName = defaultRoleSessionName
+	}
+	stsClient := sts.NewFromConfig(cfg)
+
+	if tokenFile := pluginEnvVars["AWS_WEB_IDENTITY_TOKEN_FILE"]; tokenFile != "" {
+		return stscreds.NewWebIdentityRoleProvider(stsClient, roleArn, stscreds.IdentityTokenFile(tokenFile),
+			func(o *stscreds.WebIdentityRoleOptions) {
+				o.RoleSessionName = sessionName
+			})
+	}
+	return stscreds.NewAssumeRoleProvider(stsClient, roleArn, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = sessionName
+		if externalID := pluginEnvVars["AWS_EXTERNAL_ID"]; externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	})
+}
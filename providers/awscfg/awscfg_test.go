@@ -0,0 +1,27 @@
+package awscfg
+
+import "testing"
+
+// TestCacheKeyScopesByExternalIDAndSessionName guards against two
+// connections that assume the same role with different
+// AWS_EXTERNAL_ID/AWS_ROLE_SESSION_NAME sharing a cached aws.Config (and
+// therefore its already-assumed-role credentials).
+func TestCacheKeyScopesByExternalIDAndSessionName(t *testing.T) {
+	base := map[string]string{"AWS_EXTERNAL_ID": "tenant-a", "AWS_ROLE_SESSION_NAME": "session-a"}
+	sameRole := map[string]string{"AWS_EXTERNAL_ID": "tenant-b", "AWS_ROLE_SESSION_NAME": "session-b"}
+
+	roleArn := "arn:aws:iam::000000000000:role/shared"
+	k1 := cacheKey(roleArn, base)
+	k2 := cacheKey(roleArn, sameRole)
+	if k1 == k2 {
+		t.Fatalf("cacheKey ignored external id/session name: both got %q", k1)
+	}
+}
+
+func TestCacheKeyStableForSameInputs(t *testing.T) {
+	pluginEnvVars := map[string]string{"AWS_EXTERNAL_ID": "tenant-a", "AWS_ROLE_SESSION_NAME": "session-a"}
+	roleArn := "arn:aws:iam::000000000000:role/shared"
+	if cacheKey(roleArn, pluginEnvVars) != cacheKey(roleArn, pluginEnvVars) {
+		t.Fatal("cacheKey is not deterministic for identical inputs")
+	}
+}
@@ -0,0 +1,65 @@
+// Package awsssm implements the "awsssm" secret provider, backed by AWS
+// Systems Manager Parameter Store.
+package awsssm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+
+	"github.com/hoophq/plugin-secretsmanager/providers"
+	"github.com/hoophq/plugin-secretsmanager/providers/awscfg"
+)
+
+func init() {
+	providers.Register("awsssm", New)
+}
+
+type provider struct {
+	svc *ssm.Client
+}
+
+// New builds the awsssm provider. Credentials and region come from the
+// default AWS config chain by default; see awscfg.Load for how AWS_REGION,
+// AWS_ENDPOINT_URL and the AWS_ASSUME_ROLE_ARN family of pluginEnvVars
+// influence it.
+func New(pluginEnvVars map[string]string) (providers.Provider, error) {
+	cfg, err := awscfg.Load(context.Background(), pluginEnvVars)
+	if err != nil {
+		return nil, err
+	}
+	return &provider{svc: ssm.NewFromConfig(cfg)}, nil
+}
+
+// Resolve fetches secretID as a decrypted SSM parameter. secretKey is
+// optional: when set, the parameter value is parsed as a JSON object and
+// secretKey is extracted from it.
+func (p *provider) Resolve(ctx context.Context, secretID, secretKey string, _ url.Values) (string, error) {
+	out, err := p.svc.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(secretID),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	if out.Parameter == nil || out.Parameter.Value == nil {
+		return "", fmt.Errorf("parameter %q has no value", secretID)
+	}
+	val := *out.Parameter.Value
+	if secretKey == "" {
+		return val, nil
+	}
+	var keyVal map[string]string
+	if err := json.Unmarshal([]byte(val), &keyVal); err != nil {
+		return "", fmt.Errorf("failed deserializing parameter %q as json key/val", secretID)
+	}
+	secretVal, ok := keyVal[secretKey]
+	if !ok {
+		return "", fmt.Errorf("key not found, secretid=%s, secretkey=%s", secretID, secretKey)
+	}
+	return secretVal, nil
+}
@@ -0,0 +1,70 @@
+// Package azurekv implements the "azurekv" secret provider, backed by Azure
+// Key Vault.
+package azurekv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+
+	"github.com/hoophq/plugin-secretsmanager/providers"
+)
+
+func init() {
+	providers.Register("azurekv", New)
+}
+
+type provider struct {
+	client *azsecrets.Client
+}
+
+// New builds the azurekv provider from AZURE_TENANT_ID, AZURE_CLIENT_ID,
+// AZURE_CLIENT_SECRET and AZURE_VAULT_URL in pluginEnvVars.
+func New(pluginEnvVars map[string]string) (providers.Provider, error) {
+	vaultURL := pluginEnvVars["AZURE_VAULT_URL"]
+	if vaultURL == "" {
+		return nil, fmt.Errorf("azurekv provider requires AZURE_VAULT_URL")
+	}
+	tenantID := pluginEnvVars["AZURE_TENANT_ID"]
+	clientID := pluginEnvVars["AZURE_CLIENT_ID"]
+	clientSecret := pluginEnvVars["AZURE_CLIENT_SECRET"]
+	cred, err := azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, nil)
+	if err != nil {
+		return nil, err
+	}
+	client, err := azsecrets.NewClient(vaultURL, cred, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &provider{client: client}, nil
+}
+
+// Resolve fetches secretID's current value, or the version given in
+// query["version"]. secretKey is optional: when set, the value is parsed as
+// a JSON object and secretKey is extracted from it.
+func (p *provider) Resolve(ctx context.Context, secretID, secretKey string, query url.Values) (string, error) {
+	resp, err := p.client.GetSecret(ctx, secretID, query.Get("version"), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed accessing azure secret %q, err=%v", secretID, err)
+	}
+	if resp.Value == nil {
+		return "", fmt.Errorf("secret %q has no value", secretID)
+	}
+	val := *resp.Value
+	if secretKey == "" {
+		return val, nil
+	}
+	var keyVal map[string]string
+	if err := json.Unmarshal([]byte(val), &keyVal); err != nil {
+		return "", fmt.Errorf("failed deserializing secret %q as json key/val", secretID)
+	}
+	secretVal, ok := keyVal[secretKey]
+	if !ok {
+		return "", fmt.Errorf("key not found, secretid=%s, secretkey=%s", secretID, secretKey)
+	}
+	return secretVal, nil
+}
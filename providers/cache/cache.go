@@ -0,0 +1,114 @@
+// Package cache wraps a providers.Provider with an in-memory TTL cache so
+// repeated OnSessionOpen calls resolving the same secret don't re-hit the
+// backend on every session, and coalesces concurrent lookups for the same
+// key with singleflight.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/hoophq/plugin-secretsmanager/providers"
+)
+
+type entry struct {
+	val       string
+	expiresAt time.Time
+}
+
+func (e *entry) stale() bool { return time.Now().After(e.expiresAt) }
+
+// provider is a providers.Provider decorator that caches resolved values for
+// ttl and coalesces concurrent resolutions of the same key. Once an entry
+// goes stale it's still served (stale-while-revalidate) while a background
+// goroutine refreshes it, so a slow or flaky backend never blocks a session
+// that already has a cached value.
+type provider struct {
+	next providers.Provider
+	ttl  time.Duration
+	max  int
+
+	mu    sync.Mutex
+	items map[string]*entry
+	group singleflight.Group
+}
+
+// Wrap returns next decorated with a TTL cache. A ttl <= 0 disables caching
+// and returns next unchanged.
+func Wrap(next providers.Provider, ttl time.Duration, max int) providers.Provider {
+	if ttl <= 0 {
+		return next
+	}
+	return &provider{next: next, ttl: ttl, max: max, items: map[string]*entry{}}
+}
+
+// key incorporates the query suffix so a caller can force a fresh lookup by
+// bumping ?version=... on the connection value.
+func key(secretID, secretKey string, query url.Values) string {
+	return fmt.Sprintf("%s\x00%s\x00%s", secretID, secretKey, query.Encode())
+}
+
+func (p *provider) Resolve(ctx context.Context, secretID, secretKey string, query url.Values) (string, error) {
+	k := key(secretID, secretKey, query)
+
+	p.mu.Lock()
+	cached, ok := p.items[k]
+	p.mu.Unlock()
+	if ok {
+		if !cached.stale() {
+			return cached.val, nil
+		}
+		go p.refresh(k, secretID, secretKey, query)
+		return cached.val, nil
+	}
+
+	val, err, _ := p.group.Do(k, func() (any, error) {
+		// A caller that missed the cache just before another flight for k
+		// finished finds the stored value here instead of re-resolving.
+		p.mu.Lock()
+		cached, ok := p.items[k]
+		p.mu.Unlock()
+		if ok {
+			return cached.val, nil
+		}
+		secretVal, err := p.next.Resolve(ctx, secretID, secretKey, query)
+		if err != nil {
+			return "", err
+		}
+		p.store(k, secretVal)
+		return secretVal, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return val.(string), nil
+}
+
+func (p *provider) refresh(k, secretID, secretKey string, query url.Values) {
+	val, err, _ := p.group.Do(k, func() (any, error) {
+		return p.next.Resolve(context.Background(), secretID, secretKey, query)
+	})
+	if err != nil {
+		// keep serving the stale value until the backend recovers
+		return
+	}
+	p.store(k, val.(string))
+}
+
+func (p *provider) store(k, val string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.max > 0 && len(p.items) >= p.max {
+		// best-effort cap, not a real LRU: drop one arbitrary entry to make room
+		for evict := range p.items {
+			delete(p.items, evict)
+			break
+		}
+	}
+	p.items[k] = &entry{val: val, expiresAt: time.Now().Add(p.ttl)}
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingProvider is a providers.Provider stub that counts Resolve calls and
+// returns whatever val currently holds, so tests can flip it between calls to
+// tell a refreshed value apart from a stale one.
+type countingProvider struct {
+	calls int64
+	mu    sync.Mutex
+	val   string
+}
+
+func (p *countingProvider) setVal(val string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.val = val
+}
+
+func (p *countingProvider) Resolve(ctx context.Context, secretID, secretKey string, query url.Values) (string, error) {
+	atomic.AddInt64(&p.calls, 1)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.val, nil
+}
+
+func (p *countingProvider) callCount() int64 { return atomic.LoadInt64(&p.calls) }
+
+func TestResolveServesCachedValueUntilTTLExpires(t *testing.T) {
+	next := &countingProvider{val: "s3cr3t"}
+	p := Wrap(next, 20*time.Millisecond, 0)
+
+	for i := 0; i < 3; i++ {
+		val, err := p.Resolve(context.Background(), "db/prod", "password", url.Values{})
+		if err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+		if val != "s3cr3t" {
+			t.Fatalf("got %q, want s3cr3t", val)
+		}
+	}
+	if got := next.callCount(); got != 1 {
+		t.Fatalf("backend hit %d times within TTL, want 1 (cache not reused)", got)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	next.setVal("rotated")
+	deadline := time.Now().Add(time.Second)
+	for {
+		val, err := p.Resolve(context.Background(), "db/prod", "password", url.Values{})
+		if err != nil {
+			t.Fatalf("Resolve after TTL expiry: %v", err)
+		}
+		if val == "rotated" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("value never refreshed after TTL expiry, still serving %q", val)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestResolveServesStaleValueWhileRefreshingInBackground(t *testing.T) {
+	next := &countingProvider{val: "s3cr3t"}
+	p := Wrap(next, 10*time.Millisecond, 0)
+
+	if _, err := p.Resolve(context.Background(), "db/prod", "password", url.Values{}); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	next.setVal("rotated")
+
+	// First call past TTL must return the stale value immediately rather
+	// than blocking on a refresh.
+	val, err := p.Resolve(context.Background(), "db/prod", "password", url.Values{})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if val != "s3cr3t" {
+		t.Fatalf("got %q, want stale value s3cr3t served immediately", val)
+	}
+
+	// The background refresh it kicked off should land shortly after.
+	deadline := time.Now().Add(time.Second)
+	for {
+		val, err := p.Resolve(context.Background(), "db/prod", "password", url.Values{})
+		if err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+		if val == "rotated" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("background refresh never landed, still serving %q", val)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestResolveCoalescesConcurrentCallsForSameKey(t *testing.T) {
+	next := &countingProvider{val: "s3cr3t"}
+	p := Wrap(next, time.Minute, 0)
+
+	const n = 20
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			val, err := p.Resolve(context.Background(), "db/prod", "password", url.Values{})
+			if err != nil {
+				t.Errorf("Resolve: %v", err)
+			}
+			if val != "s3cr3t" {
+				t.Errorf("got %q, want s3cr3t", val)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := next.callCount(); got != 1 {
+		t.Fatalf("backend hit %d times for %d concurrent callers of the same key, want 1", got, n)
+	}
+}
+
+func TestResolveEvictsOnceMaxIsReached(t *testing.T) {
+	next := &countingProvider{val: "s3cr3t"}
+	p := Wrap(next, time.Minute, 2).(*provider)
+
+	p.store("a", "1")
+	p.store("b", "2")
+	p.store("c", "3")
+
+	if got := len(p.items); got != 2 {
+		t.Fatalf("cache has %d entries after exceeding max, want 2 (no eviction)", got)
+	}
+}
@@ -0,0 +1,44 @@
+// Package envjson implements the "envjson" secret provider, which resolves
+// secrets from a JSON object stored in a plain environment variable. It's
+// meant for local development and setups where secrets are already injected
+// by the surrounding orchestrator (e.g. Kubernetes secret volumes projected
+// as env vars).
+package envjson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/hoophq/plugin-secretsmanager/providers"
+)
+
+func init() {
+	providers.Register("envjson", New)
+}
+
+type provider struct{}
+
+// New builds the envjson provider. It requires no configuration since it
+// reads directly from the process environment at resolve time.
+func New(_ map[string]string) (providers.Provider, error) {
+	return &provider{}, nil
+}
+
+func (p *provider) Resolve(_ context.Context, secretID, secretKey string, _ url.Values) (string, error) {
+	envJSON := os.Getenv(secretID)
+	if envJSON == "" {
+		return "", fmt.Errorf("env not found for secret id %q", secretID)
+	}
+	var envMap map[string]string
+	if err := json.Unmarshal([]byte(envJSON), &envMap); err != nil {
+		return "", fmt.Errorf("failed decoding secret id %q to json, err=%v", secretID, err)
+	}
+	val, ok := envMap[secretKey]
+	if !ok {
+		return "", fmt.Errorf("secret key %q not found in secret id %q", secretKey, secretID)
+	}
+	return val, nil
+}
@@ -0,0 +1,72 @@
+// Package gcpsm implements the "gcpsm" secret provider, backed by Google
+// Cloud Secret Manager.
+package gcpsm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/api/option"
+
+	"github.com/hoophq/plugin-secretsmanager/providers"
+)
+
+func init() {
+	providers.Register("gcpsm", New)
+}
+
+type provider struct {
+	client    *secretmanager.Client
+	projectID string
+}
+
+// New builds the gcpsm provider from GCP_PROJECT_ID and GCP_CREDENTIALS_JSON
+// (a service-account key, as downloaded from the GCP console) in
+// pluginEnvVars.
+func New(pluginEnvVars map[string]string) (providers.Provider, error) {
+	projectID := pluginEnvVars["GCP_PROJECT_ID"]
+	if projectID == "" {
+		return nil, fmt.Errorf("gcpsm provider requires GCP_PROJECT_ID")
+	}
+	creds := pluginEnvVars["GCP_CREDENTIALS_JSON"]
+	if creds == "" {
+		return nil, fmt.Errorf("gcpsm provider requires GCP_CREDENTIALS_JSON")
+	}
+	client, err := secretmanager.NewClient(context.Background(), option.WithCredentialsJSON([]byte(creds)))
+	if err != nil {
+		return nil, err
+	}
+	return &provider{client: client, projectID: projectID}, nil
+}
+
+// Resolve fetches secretID's payload. version defaults to "latest" and can
+// be overridden with query["version"]. secretKey is optional: when set, the
+// payload is parsed as a JSON object and secretKey is extracted from it.
+func (p *provider) Resolve(ctx context.Context, secretID, secretKey string, query url.Values) (string, error) {
+	version := query.Get("version")
+	if version == "" {
+		version = "latest"
+	}
+	name := fmt.Sprintf("projects/%s/secrets/%s/versions/%s", p.projectID, secretID, version)
+	resp, err := p.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: name})
+	if err != nil {
+		return "", fmt.Errorf("failed accessing gcp secret %q, err=%v", secretID, err)
+	}
+	payload := resp.GetPayload().GetData()
+	if secretKey == "" {
+		return string(payload), nil
+	}
+	var keyVal map[string]string
+	if err := json.Unmarshal(payload, &keyVal); err != nil {
+		return "", fmt.Errorf("failed deserializing secret %q as json key/val", secretID)
+	}
+	val, ok := keyVal[secretKey]
+	if !ok {
+		return "", fmt.Errorf("key not found, secretid=%s, secretkey=%s", secretID, secretKey)
+	}
+	return val, nil
+}
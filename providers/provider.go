@@ -0,0 +1,26 @@
+// Package providers implements the pluggable secret-backend registry used by
+// the secretsmanager plugin. Each backend (AWS Secrets Manager, AWS SSM
+// Parameter Store, HashiCorp Vault, GCP Secret Manager, Azure Key Vault, ...)
+// implements Provider and registers itself under a scheme name so the
+// connection value dispatcher can route `<scheme>:<secret-id>:<secret-key>`
+// strings to the right backend without main.go knowing about any of them.
+package providers
+
+import (
+	"context"
+	"net/url"
+)
+
+// Provider resolves a single secret value from a concrete backend.
+type Provider interface {
+	// Resolve fetches secretKey from secretID. secretKey may be empty for
+	// backends that store a single scalar value. query carries any
+	// provider-specific suffix parsed out of the connection value, such as
+	// ?version=... or ?stage=AWSCURRENT.
+	Resolve(ctx context.Context, secretID, secretKey string, query url.Values) (string, error)
+}
+
+// Factory builds a Provider for a scheme, reading whatever configuration it
+// needs from pluginEnvVars. Factories are invoked lazily, the first time a
+// connection value references their scheme.
+type Factory func(pluginEnvVars map[string]string) (Provider, error)
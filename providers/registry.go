@@ -0,0 +1,21 @@
+package providers
+
+import "fmt"
+
+var factories = map[string]Factory{}
+
+// Register associates a scheme name (e.g. "vault", "awsssm") with a Factory.
+// It's meant to be called from the init() of each provider package.
+func Register(scheme string, factory Factory) {
+	factories[scheme] = factory
+}
+
+// New builds the Provider registered for scheme, or an error if no provider
+// has registered under that name.
+func New(scheme string, pluginEnvVars map[string]string) (Provider, error) {
+	factory, ok := factories[scheme]
+	if !ok {
+		return nil, fmt.Errorf("secret provider %q not implemented", scheme)
+	}
+	return factory(pluginEnvVars)
+}
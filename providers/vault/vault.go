@@ -0,0 +1,77 @@
+// Package vault implements the "vault" secret provider, backed by a
+// HashiCorp Vault KV v2 secrets engine.
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/hoophq/plugin-secretsmanager/providers"
+)
+
+func init() {
+	providers.Register("vault", New)
+}
+
+type provider struct {
+	client *vaultapi.Client
+	mount  string
+}
+
+// New builds the vault provider from VAULT_ADDR, VAULT_TOKEN and VAULT_MOUNT
+// (defaults to "secret") in pluginEnvVars.
+func New(pluginEnvVars map[string]string) (providers.Provider, error) {
+	addr := pluginEnvVars["VAULT_ADDR"]
+	if addr == "" {
+		return nil, fmt.Errorf("vault provider requires VAULT_ADDR")
+	}
+	token := pluginEnvVars["VAULT_TOKEN"]
+	if token == "" {
+		return nil, fmt.Errorf("vault provider requires VAULT_TOKEN")
+	}
+	mount := pluginEnvVars["VAULT_MOUNT"]
+	if mount == "" {
+		mount = "secret"
+	}
+	cfg := vaultapi.DefaultConfig()
+	cfg.Address = addr
+	client, err := vaultapi.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken(token)
+	return &provider{client: client, mount: mount}, nil
+}
+
+// Resolve reads secretID from the KV v2 "data" path and extracts secretKey
+// from its data map. query["version"] selects a specific KV v2 version.
+func (p *provider) Resolve(ctx context.Context, secretID, secretKey string, query url.Values) (string, error) {
+	path := fmt.Sprintf("%s/data/%s", p.mount, secretID)
+	params := map[string][]string{}
+	if version := query.Get("version"); version != "" {
+		params["version"] = []string{version}
+	}
+	secret, err := p.client.Logical().ReadWithDataWithContext(ctx, path, params)
+	if err != nil {
+		return "", fmt.Errorf("failed reading vault secret %q, err=%v", secretID, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return "", fmt.Errorf("vault secret %q not found", secretID)
+	}
+	data, ok := secret.Data["data"].(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("vault secret %q has no kv-v2 data payload", secretID)
+	}
+	val, ok := data[secretKey]
+	if !ok {
+		return "", fmt.Errorf("key not found, secretid=%s, secretkey=%s", secretID, secretKey)
+	}
+	strVal, ok := val.(string)
+	if !ok {
+		return "", fmt.Errorf("secret key %q in %q is not a string value", secretKey, secretID)
+	}
+	return strVal, nil
+}
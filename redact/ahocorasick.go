@@ -0,0 +1,96 @@
+package redact
+
+// automaton is a minimal Aho-Corasick automaton used to find every
+// occurrence of a set of byte patterns in a single pass over the input,
+// regardless of how many patterns are registered.
+type automaton struct {
+	children []map[byte]int
+	fail     []int
+	out      [][]int // pattern indices matched ending at this node
+	patterns [][]byte
+}
+
+// match is an occurrence of a registered pattern at payload[start:end].
+type match struct {
+	start, end int
+}
+
+func newAutomaton(patterns [][]byte) *automaton {
+	a := &automaton{
+		children: []map[byte]int{{}},
+		fail:     []int{0},
+		out:      [][]int{nil},
+		patterns: patterns,
+	}
+	for i, p := range patterns {
+		cur := 0
+		for _, b := range p {
+			next, ok := a.children[cur][b]
+			if !ok {
+				a.children = append(a.children, map[byte]int{})
+				a.fail = append(a.fail, 0)
+				a.out = append(a.out, nil)
+				next = len(a.children) - 1
+				a.children[cur][b] = next
+			}
+			cur = next
+		}
+		a.out[cur] = append(a.out[cur], i)
+	}
+	a.buildFailLinks()
+	return a
+}
+
+func (a *automaton) buildFailLinks() {
+	var queue []int
+	for _, child := range a.children[0] {
+		a.fail[child] = 0
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for b, v := range a.children[u] {
+			queue = append(queue, v)
+			f := a.fail[u]
+			for {
+				if next, ok := a.children[f][b]; ok {
+					a.fail[v] = next
+					break
+				}
+				if f == 0 {
+					a.fail[v] = 0
+					break
+				}
+				f = a.fail[f]
+			}
+			a.out[v] = append(a.out[v], a.out[a.fail[v]]...)
+		}
+	}
+}
+
+func (a *automaton) next(state int, b byte) int {
+	for {
+		if n, ok := a.children[state][b]; ok {
+			return n
+		}
+		if state == 0 {
+			return 0
+		}
+		state = a.fail[state]
+	}
+}
+
+// findAll returns every occurrence of any registered pattern in payload.
+func (a *automaton) findAll(payload []byte) []match {
+	var matches []match
+	state := 0
+	for i, b := range payload {
+		state = a.next(state, b)
+		for _, patIdx := range a.out[state] {
+			plen := len(a.patterns[patIdx])
+			matches = append(matches, match{start: i - plen + 1, end: i + 1})
+		}
+	}
+	return matches
+}
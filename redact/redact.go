@@ -0,0 +1,170 @@
+// Package redact scans session packet payloads for resolved secret values
+// and masks any occurrence of them, so a database result set, shell output
+// or any other payload that happens to echo a secret never reaches the log
+// stream or the client in the clear.
+package redact
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const mask = "#######"
+
+// maxSessions bounds how many sessions Table tracks at once. pluginhooks.Plugin
+// has no session-close hook to tell Table a session is done, so without a
+// bound byS would grow by one entry per session for the lifetime of the
+// plugin process.
+const maxSessions = 4096
+
+// sessionIdleTTL is how long a session's entry must go without a Redact call
+// touching it before Set, once the table is at maxSessions, reclaims it as
+// idle; entries are not dropped on a timer. Set touches an entry too, so a
+// session idling between OnSessionOpen and its first payload doesn't get
+// reclaimed out from under it.
+const sessionIdleTTL = 30 * time.Minute
+
+// tableEntry pairs a session's redaction automaton with the last time it was
+// used, so Set can reclaim idle (almost certainly closed) sessions first
+// instead of refusing new ones once the table fills up.
+type tableEntry struct {
+	a        *automaton
+	lastSeen time.Time
+}
+
+// Table keeps, per session, every secret value resolved during
+// OnSessionOpen and an automaton able to find all of their occurrences (and
+// common encodings of them) in a single pass over a payload.
+type Table struct {
+	mu  sync.Mutex
+	byS map[string]*tableEntry
+}
+
+// NewTable builds an empty redaction table.
+func NewTable() *Table {
+	return &Table{byS: map[string]*tableEntry{}}
+}
+
+// Set registers the secret values resolved for sessionID, replacing any
+// previous set for that session. If the table is at capacity, Set first
+// reclaims sessions idle past sessionIdleTTL and, failing that, the single
+// least-recently-used session, so a new session is never silently refused
+// an entry (which would leave it unredacted for the rest of the process's
+// life).
+func (t *Table) Set(sessionID string, values []string) {
+	patterns := variants(values)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(patterns) == 0 {
+		delete(t.byS, sessionID)
+		return
+	}
+	if _, exists := t.byS[sessionID]; !exists && len(t.byS) >= maxSessions {
+		t.evictIdleLocked()
+	}
+	if _, exists := t.byS[sessionID]; !exists && len(t.byS) >= maxSessions {
+		t.evictLRULocked()
+	}
+	t.byS[sessionID] = &tableEntry{a: newAutomaton(patterns), lastSeen: time.Now()}
+}
+
+// evictIdleLocked drops every entry untouched for longer than sessionIdleTTL.
+func (t *Table) evictIdleLocked() {
+	cutoff := time.Now().Add(-sessionIdleTTL)
+	for id, e := range t.byS {
+		if e.lastSeen.Before(cutoff) {
+			delete(t.byS, id)
+		}
+	}
+}
+
+// evictLRULocked drops the least-recently-used entry.
+func (t *Table) evictLRULocked() {
+	var oldestID string
+	var oldest time.Time
+	for id, e := range t.byS {
+		if oldestID == "" || e.lastSeen.Before(oldest) {
+			oldestID, oldest = id, e.lastSeen
+		}
+	}
+	if oldestID != "" {
+		delete(t.byS, oldestID)
+	}
+}
+
+// Redact returns payload with every occurrence of a secret registered for
+// sessionID replaced by a fixed mask. It returns payload unmodified (same
+// slice) when sessionID has no registered secrets or none are found.
+func (t *Table) Redact(sessionID string, payload []byte) []byte {
+	t.mu.Lock()
+	e, ok := t.byS[sessionID]
+	if ok {
+		e.lastSeen = time.Now()
+	}
+	t.mu.Unlock()
+	if !ok {
+		return payload
+	}
+	matches := e.a.findAll(payload)
+	if len(matches) == 0 {
+		return payload
+	}
+	return applyMask(payload, matches)
+}
+
+// applyMask merges overlapping/adjacent matches and rewrites payload with
+// mask in place of each one.
+func applyMask(payload []byte, matches []match) []byte {
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	merged := matches[:1]
+	for _, m := range matches[1:] {
+		last := &merged[len(merged)-1]
+		if m.start <= last.end {
+			if m.end > last.end {
+				last.end = m.end
+			}
+			continue
+		}
+		merged = append(merged, m)
+	}
+
+	out := make([]byte, 0, len(payload))
+	prev := 0
+	for _, m := range merged {
+		out = append(out, payload[prev:m.start]...)
+		out = append(out, mask...)
+		prev = m.end
+	}
+	out = append(out, payload[prev:]...)
+	return out
+}
+
+// variants expands each secret value into the plain value plus its base64,
+// URL-encoded and JSON-string-escaped forms, since a secret can reach a
+// payload through any of those encodings (e.g. a driver connecting with a
+// URL-encoded password, or a value echoed back inside a JSON response).
+func variants(values []string) [][]byte {
+	seen := map[string]bool{}
+	var out [][]byte
+	add := func(s string) {
+		if s == "" || seen[s] {
+			return
+		}
+		seen[s] = true
+		out = append(out, []byte(s))
+	}
+	for _, v := range values {
+		add(v)
+		add(base64.StdEncoding.EncodeToString([]byte(v)))
+		add(url.QueryEscape(v))
+		if escaped, err := json.Marshal(v); err == nil {
+			// json.Marshal wraps strings in quotes; keep just the escaped body.
+			add(string(escaped[1 : len(escaped)-1]))
+		}
+	}
+	return out
+}
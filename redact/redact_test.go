@@ -0,0 +1,62 @@
+package redact
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestSetBoundsSessionCount guards against byS growing without bound: there
+// is no session-close hook to remove an entry once a session ends, so Set
+// must cap how many sessions it tracks at once instead of growing forever.
+func TestSetBoundsSessionCount(t *testing.T) {
+	table := NewTable()
+	for i := 0; i < maxSessions+10; i++ {
+		table.Set(fmt.Sprintf("session-%d", i), []string{"s3cr3t"})
+	}
+	if got := len(table.byS); got > maxSessions {
+		t.Fatalf("byS has %d entries, want at most %d", got, maxSessions)
+	}
+}
+
+// TestSetAtCapEvictsLeastRecentlyUsed guards against a full table silently
+// refusing a new session's entry (which would leave it unredacted for the
+// rest of the process's life): a new session must always get an entry, at
+// the cost of evicting whichever tracked session has gone longest untouched.
+func TestSetAtCapEvictsLeastRecentlyUsed(t *testing.T) {
+	table := NewTable()
+	for i := 0; i < maxSessions; i++ {
+		table.Set(fmt.Sprintf("session-%d", i), []string{"s3cr3t"})
+	}
+	// Touch session-0 so it's not the least-recently-used entry.
+	table.Redact("session-0", []byte("noop"))
+
+	table.Set("one-too-many", []string{"s3cr3t"})
+
+	got := table.Redact("one-too-many", []byte("password=s3cr3t"))
+	if string(got) != "password=#######" {
+		t.Fatalf("new session not redacted after table hit cap: got %q", got)
+	}
+	got = table.Redact("session-0", []byte("password=s3cr3t"))
+	if string(got) != "password=#######" {
+		t.Fatalf("recently-used session lost redaction after table hit cap: got %q", got)
+	}
+}
+
+func TestRedactMasksRegisteredSecret(t *testing.T) {
+	table := NewTable()
+	table.Set("session-1", []string{"s3cr3t"})
+
+	got := table.Redact("session-1", []byte("password=s3cr3t"))
+	if string(got) != "password=#######" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestRedactUnknownSessionReturnsPayloadUnmodified(t *testing.T) {
+	table := NewTable()
+	payload := []byte("password=s3cr3t")
+	got := table.Redact("unknown", payload)
+	if string(got) != string(payload) {
+		t.Fatalf("got %q, want payload untouched", got)
+	}
+}